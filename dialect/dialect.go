@@ -0,0 +1,73 @@
+// Package dialect isolates the handful of operations that differ between
+// SQL backends, so the rest of liteflow can stay backend-agnostic: how the
+// schema version is stored, how a migration file's statements are split for
+// execution, and how positional parameters are written.
+package dialect
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// Querier is satisfied by *sql.DB and *sql.Tx, letting a Dialect run its
+// version bookkeeping queries against either.
+type Querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Dialect implements the backend-specific parts of migration bookkeeping.
+type Dialect interface {
+	// EnsureVersionTable creates whatever backing store CurrentVersion and
+	// SetVersion need, if they need one. It is called once per New/NewContext
+	// call that has a VersionFS, not on every version check, so a multi-file
+	// Upgrade/Downgrade
+	// doesn't reissue the same DDL once per migration.
+	EnsureVersionTable(ctx context.Context, q Querier) error
+
+	// CurrentVersion returns the schema version currently applied.
+	CurrentVersion(ctx context.Context, q Querier) (int, error)
+
+	// SetVersion records that version v has been applied.
+	SetVersion(ctx context.Context, q Querier, v int) error
+
+	// SplitStatements splits a migration file's contents into the
+	// individual statements it should be executed as.
+	SplitStatements(src string) ([]string, error)
+
+	// Placeholder returns the positional parameter placeholder for the
+	// n'th (1-indexed) bound argument.
+	Placeholder(n int) string
+
+	// HistoryTableDDL returns the "CREATE TABLE IF NOT EXISTS" statement for
+	// the migration-history table, using this dialect's own auto-increment
+	// primary-key syntax (SQLite and MySQL differ from Postgres here, and
+	// there is no portable "rowid" to fall back on).
+	HistoryTableDDL(table string) string
+
+	// InsertHistoryRecord inserts a new migration-history row and returns
+	// its primary key id. This is a Dialect method, rather than a plain
+	// Exec plus sql.Result.LastInsertId, because Postgres's driver does not
+	// support LastInsertId and needs "RETURNING id" instead.
+	InsertHistoryRecord(ctx context.Context, q Querier, table string, version int, filename, direction, checksum, appliedAt string) (int64, error)
+}
+
+// SplitOnSemicolons is a naive statement splitter shared by dialects whose
+// drivers cannot run a semicolon-separated batch in a single Exec call. It
+// does not understand string-embedded semicolons or PL/pgSQL-style blocks;
+// callers with those needs should pre-process with the goose-style
+// "-- +liteflow StatementBegin/End" annotations instead. It is also reused
+// by liteflow's migration-file parser to split the freeform SQL outside
+// such a block, regardless of which Dialect is configured.
+func SplitOnSemicolons(src string) ([]string, error) {
+	var stmts []string
+	for _, part := range strings.Split(src, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		stmts = append(stmts, part)
+	}
+	return stmts, nil
+}
@@ -0,0 +1,66 @@
+package dialect
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitOnSemicolons(t *testing.T) {
+	cases := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{"empty", "", nil},
+		{"single", "SELECT 1", []string{"SELECT 1"}},
+		{
+			"multiple",
+			"CREATE TABLE t (id INTEGER);\nINSERT INTO t VALUES (1);",
+			[]string{"CREATE TABLE t (id INTEGER)", "INSERT INTO t VALUES (1)"},
+		},
+		{
+			"trailing and blank statements are dropped",
+			";;SELECT 1;;  ;",
+			[]string{"SELECT 1"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := SplitOnSemicolons(tc.src)
+			if err != nil {
+				t.Fatalf("SplitOnSemicolons(%q) returned error: %v", tc.src, err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("SplitOnSemicolons(%q) = %#v, want %#v", tc.src, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPlaceholder(t *testing.T) {
+	if got := SQLite.Placeholder(3); got != "?" {
+		t.Errorf("SQLite.Placeholder(3) = %q, want \"?\"", got)
+	}
+	if got := MySQL.Placeholder(3); got != "?" {
+		t.Errorf("MySQL.Placeholder(3) = %q, want \"?\"", got)
+	}
+	if got := Postgres.Placeholder(1); got != "$1" {
+		t.Errorf("Postgres.Placeholder(1) = %q, want \"$1\"", got)
+	}
+	if got := Postgres.Placeholder(12); got != "$12" {
+		t.Errorf("Postgres.Placeholder(12) = %q, want \"$12\"", got)
+	}
+}
+
+func TestHistoryTableDDLIncludesPrimaryKey(t *testing.T) {
+	for _, d := range []Dialect{SQLite, MySQL, Postgres} {
+		ddl := d.HistoryTableDDL("liteflow_migrations")
+		if ddl == "" {
+			t.Fatalf("%T.HistoryTableDDL returned empty string", d)
+		}
+		if !strings.Contains(ddl, "PRIMARY KEY") {
+			t.Errorf("%T.HistoryTableDDL = %q, want a PRIMARY KEY column", d, ddl)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package dialect
+
+import (
+	"context"
+	"fmt"
+)
+
+// MySQL tracks the schema version in a `schema_migrations` table, since
+// MySQL has no equivalent of SQLite's `PRAGMA user_version`.
+var MySQL Dialect = mysqlDialect{}
+
+type mysqlDialect struct{}
+
+// EnsureVersionTable creates the schema_migrations table once per
+// New/NewContext call that has a VersionFS, rather than CurrentVersion redoing the same
+// "CREATE TABLE IF NOT EXISTS" on every version check.
+func (mysqlDialect) EnsureVersionTable(ctx context.Context, q Querier) error {
+	if _, err := q.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER NOT NULL)`); err != nil {
+		return fmt.Errorf("could not ensure schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+func (mysqlDialect) CurrentVersion(ctx context.Context, q Querier) (int, error) {
+	var v int
+	if err := q.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&v); err != nil {
+		return 0, fmt.Errorf("could not query schema_migrations: %w", err)
+	}
+	return v, nil
+}
+
+// SetVersion replaces the table's contents with a single row holding v, so
+// CurrentVersion's MAX(version) tracks the current version exactly like
+// SQLite's `PRAGMA user_version` rather than only ever growing: a downgrade
+// clears the higher version(s) a prior upgrade left behind instead of
+// leaving them to make CurrentVersion report a version that was undone.
+func (mysqlDialect) SetVersion(ctx context.Context, q Querier, v int) error {
+	if _, err := q.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return fmt.Errorf("could not clear schema_migrations: %w", err)
+	}
+	if _, err := q.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES (?)`, v); err != nil {
+		return fmt.Errorf("could not insert schema_migrations version %d: %w", v, err)
+	}
+	return nil
+}
+
+func (mysqlDialect) SplitStatements(src string) ([]string, error) {
+	return SplitOnSemicolons(src)
+}
+
+func (mysqlDialect) Placeholder(int) string {
+	return "?"
+}
+
+func (mysqlDialect) HistoryTableDDL(table string) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		version INTEGER NOT NULL,
+		filename TEXT NOT NULL,
+		direction VARCHAR(16) NOT NULL,
+		checksum VARCHAR(64) NOT NULL,
+		applied_at VARCHAR(64) NOT NULL,
+		duration_ms BIGINT NOT NULL,
+		dirty TINYINT NOT NULL DEFAULT 1
+	)`, table)
+}
+
+func (mysqlDialect) InsertHistoryRecord(ctx context.Context, q Querier, table string, version int, filename, direction, checksum, appliedAt string) (int64, error) {
+	insertSql := fmt.Sprintf("INSERT INTO %s (version, filename, direction, checksum, applied_at, duration_ms, dirty) VALUES (?, ?, ?, ?, ?, 0, 1)", table)
+	res, err := q.ExecContext(ctx, insertSql, version, filename, direction, checksum, appliedAt)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
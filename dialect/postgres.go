@@ -0,0 +1,77 @@
+package dialect
+
+import (
+	"context"
+	"fmt"
+)
+
+// Postgres tracks the schema version in a `schema_migrations` table, since
+// Postgres has no equivalent of SQLite's `PRAGMA user_version`.
+var Postgres Dialect = postgresDialect{}
+
+type postgresDialect struct{}
+
+// EnsureVersionTable creates the schema_migrations table once per
+// New/NewContext call that has a VersionFS, rather than CurrentVersion redoing the same
+// "CREATE TABLE IF NOT EXISTS" on every version check.
+func (postgresDialect) EnsureVersionTable(ctx context.Context, q Querier) error {
+	if _, err := q.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER NOT NULL)`); err != nil {
+		return fmt.Errorf("could not ensure schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+func (postgresDialect) CurrentVersion(ctx context.Context, q Querier) (int, error) {
+	var v int
+	if err := q.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&v); err != nil {
+		return 0, fmt.Errorf("could not query schema_migrations: %w", err)
+	}
+	return v, nil
+}
+
+// SetVersion replaces the table's contents with a single row holding v, so
+// CurrentVersion's MAX(version) tracks the current version exactly like
+// SQLite's `PRAGMA user_version` rather than only ever growing: a downgrade
+// clears the higher version(s) a prior upgrade left behind instead of
+// leaving them to make CurrentVersion report a version that was undone.
+func (postgresDialect) SetVersion(ctx context.Context, q Querier, v int) error {
+	if _, err := q.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return fmt.Errorf("could not clear schema_migrations: %w", err)
+	}
+	if _, err := q.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, v); err != nil {
+		return fmt.Errorf("could not insert schema_migrations version %d: %w", v, err)
+	}
+	return nil
+}
+
+func (postgresDialect) SplitStatements(src string) ([]string, error) {
+	return SplitOnSemicolons(src)
+}
+
+func (postgresDialect) Placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+func (postgresDialect) HistoryTableDDL(table string) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id SERIAL PRIMARY KEY,
+		version INTEGER NOT NULL,
+		filename TEXT NOT NULL,
+		direction TEXT NOT NULL,
+		checksum TEXT NOT NULL,
+		applied_at TEXT NOT NULL,
+		duration_ms INTEGER NOT NULL,
+		dirty INTEGER NOT NULL DEFAULT 1
+	)`, table)
+}
+
+// InsertHistoryRecord uses "RETURNING id" rather than
+// sql.Result.LastInsertId, since Postgres's drivers don't implement it.
+func (postgresDialect) InsertHistoryRecord(ctx context.Context, q Querier, table string, version int, filename, direction, checksum, appliedAt string) (int64, error) {
+	insertSql := fmt.Sprintf("INSERT INTO %s (version, filename, direction, checksum, applied_at, duration_ms, dirty) VALUES ($1, $2, $3, $4, $5, 0, 1) RETURNING id", table)
+	var id int64
+	if err := q.QueryRowContext(ctx, insertSql, version, filename, direction, checksum, appliedAt).Scan(&id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
@@ -0,0 +1,64 @@
+package dialect
+
+import (
+	"context"
+	"fmt"
+)
+
+// SQLite tracks the schema version in SQLite's built-in `PRAGMA user_version`
+// pragma, and relies on SQLite's driver support for running a whole file's
+// statements in a single Exec call.
+var SQLite Dialect = sqliteDialect{}
+
+type sqliteDialect struct{}
+
+// EnsureVersionTable is a no-op: SQLite tracks the version in the built-in
+// `user_version` pragma, which needs no backing table.
+func (sqliteDialect) EnsureVersionTable(ctx context.Context, q Querier) error {
+	return nil
+}
+
+func (sqliteDialect) CurrentVersion(ctx context.Context, q Querier) (int, error) {
+	var v int
+	if err := q.QueryRowContext(ctx, "PRAGMA user_version").Scan(&v); err != nil {
+		return 0, fmt.Errorf("could not query user_version: %w", err)
+	}
+	return v, nil
+}
+
+func (sqliteDialect) SetVersion(ctx context.Context, q Querier, v int) error {
+	if _, err := q.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", v)); err != nil {
+		return fmt.Errorf("could not set user_version to %d: %w", v, err)
+	}
+	return nil
+}
+
+func (sqliteDialect) SplitStatements(src string) ([]string, error) {
+	return []string{src}, nil
+}
+
+func (sqliteDialect) Placeholder(int) string {
+	return "?"
+}
+
+func (sqliteDialect) HistoryTableDDL(table string) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		version INTEGER NOT NULL,
+		filename TEXT NOT NULL,
+		direction TEXT NOT NULL,
+		checksum TEXT NOT NULL,
+		applied_at TEXT NOT NULL,
+		duration_ms INTEGER NOT NULL,
+		dirty INTEGER NOT NULL DEFAULT 1
+	)`, table)
+}
+
+func (sqliteDialect) InsertHistoryRecord(ctx context.Context, q Querier, table string, version int, filename, direction, checksum, appliedAt string) (int64, error) {
+	insertSql := fmt.Sprintf("INSERT INTO %s (version, filename, direction, checksum, applied_at, duration_ms, dirty) VALUES (?, ?, ?, ?, ?, 0, 1)", table)
+	res, err := q.ExecContext(ctx, insertSql, version, filename, direction, checksum, appliedAt)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
@@ -0,0 +1,210 @@
+package liteflow
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// Hook is a single injection point for tracing, auditing, and per-query
+// timing, called around every query/exec path on DB and Tx, and around every
+// migration file run by Upgrade/Downgrade.
+//
+// BeforeQuery/BeforeMigration may replace ctx (e.g. to start a span) and
+// return an error to abort the operation before it reaches the database.
+type Hook interface {
+	BeforeQuery(ctx context.Context, name string, args []any) (context.Context, error)
+	AfterQuery(ctx context.Context, name string, args []any, err error, rowsAffected int64)
+	BeforeMigration(ctx context.Context, version int, direction string) (context.Context, error)
+	AfterMigration(ctx context.Context, version int, direction string, err error, duration time.Duration)
+}
+
+// BaseHook is a no-op implementation of Hook, meant to be embedded so a hook
+// only needs to override the methods it cares about.
+type BaseHook struct{}
+
+func (BaseHook) BeforeQuery(ctx context.Context, name string, args []any) (context.Context, error) {
+	return ctx, nil
+}
+
+func (BaseHook) AfterQuery(ctx context.Context, name string, args []any, err error, rowsAffected int64) {
+}
+
+func (BaseHook) BeforeMigration(ctx context.Context, version int, direction string) (context.Context, error) {
+	return ctx, nil
+}
+
+func (BaseHook) AfterMigration(ctx context.Context, version int, direction string, err error, duration time.Duration) {
+}
+
+// runBeforeQuery runs every hook's BeforeQuery, threading ctx through in
+// order and stopping at the first error. It also returns the number of
+// hooks whose BeforeQuery returned without error, so the caller's matching
+// runAfterQuery call notifies only those hooks and not the one that failed
+// or ones that never got a Before call.
+func (db *DB) runBeforeQuery(ctx context.Context, name string, args []any) (context.Context, int, error) {
+	for i, h := range db.hooks {
+		var err error
+		if ctx, err = h.BeforeQuery(ctx, name, args); err != nil {
+			return ctx, i, err
+		}
+	}
+	return ctx, len(db.hooks), nil
+}
+
+// runAfterQuery runs AfterQuery for the first n hooks, where n is the count
+// returned by the matching runBeforeQuery call.
+func (db *DB) runAfterQuery(ctx context.Context, n int, name string, args []any, err error, rowsAffected int64) {
+	for _, h := range db.hooks[:n] {
+		h.AfterQuery(ctx, name, args, err, rowsAffected)
+	}
+}
+
+// runBeforeMigration runs every hook's BeforeMigration, threading ctx through
+// in order and stopping at the first error. It also returns the number of
+// hooks whose BeforeMigration returned without error, so the caller's
+// matching runAfterMigration call notifies only those hooks and not the one
+// that failed or ones that never got a Before call.
+func (db *DB) runBeforeMigration(ctx context.Context, version int, direction string) (context.Context, int, error) {
+	for i, h := range db.hooks {
+		var err error
+		if ctx, err = h.BeforeMigration(ctx, version, direction); err != nil {
+			return ctx, i, err
+		}
+	}
+	return ctx, len(db.hooks), nil
+}
+
+// runAfterMigration runs AfterMigration for the first n hooks, where n is
+// the count returned by the matching runBeforeMigration call.
+func (db *DB) runAfterMigration(ctx context.Context, n int, version int, direction string, err error, duration time.Duration) {
+	for _, h := range db.hooks[:n] {
+		h.AfterMigration(ctx, version, direction, err, duration)
+	}
+}
+
+// SlogHook logs queries that take at least Threshold to run, using Logger
+// (or slog.Default() if nil).
+type SlogHook struct {
+	BaseHook
+
+	// Threshold is the minimum query duration that triggers a log entry.
+	Threshold time.Duration
+
+	// Logger receives the slow-query log entries. Defaults to slog.Default().
+	Logger *slog.Logger
+
+	startedAt map[string]time.Time
+}
+
+func (h *SlogHook) logger() *slog.Logger {
+	if h.Logger != nil {
+		return h.Logger
+	}
+	return slog.Default()
+}
+
+func (h *SlogHook) BeforeQuery(ctx context.Context, name string, args []any) (context.Context, error) {
+	return context.WithValue(ctx, slogHookStartKey{}, time.Now()), nil
+}
+
+func (h *SlogHook) AfterQuery(ctx context.Context, name string, args []any, err error, rowsAffected int64) {
+	start, ok := ctx.Value(slogHookStartKey{}).(time.Time)
+	if !ok {
+		return
+	}
+	duration := time.Since(start)
+	if duration < h.Threshold {
+		return
+	}
+	h.logger().Warn("slow query", "statement", name, "duration", duration, "rows_affected", rowsAffected, "error", err)
+}
+
+// slogHookStartKey is an unexported context key, so SlogHook never collides
+// with a caller's own context values.
+type slogHookStartKey struct{}
+
+// MetricsHook accumulates a call count, error count, and total duration per
+// statement name, for callers who want to expose them as counters and
+// histograms without wiring up a tracing library.
+type MetricsHook struct {
+	BaseHook
+
+	calls atomic.Pointer[map[string]*statementMetrics]
+}
+
+// statementMetrics holds the running totals for a single statement name. All
+// fields are updated with atomic operations, since queries against the same
+// statement commonly run concurrently.
+type statementMetrics struct {
+	Calls             int64
+	Errors            int64
+	TotalElapsedNanos int64
+}
+
+func (h *MetricsHook) BeforeQuery(ctx context.Context, name string, args []any) (context.Context, error) {
+	return context.WithValue(ctx, metricsHookStartKey{}, time.Now()), nil
+}
+
+func (h *MetricsHook) AfterQuery(ctx context.Context, name string, args []any, err error, rowsAffected int64) {
+	start, _ := ctx.Value(metricsHookStartKey{}).(time.Time)
+	var elapsed time.Duration
+	if !start.IsZero() {
+		elapsed = time.Since(start)
+	}
+	m := h.metricsFor(name)
+	atomic.AddInt64(&m.Calls, 1)
+	if err != nil {
+		atomic.AddInt64(&m.Errors, 1)
+	}
+	atomic.AddInt64(&m.TotalElapsedNanos, int64(elapsed))
+}
+
+func (h *MetricsHook) metricsFor(name string) *statementMetrics {
+	for {
+		old := h.calls.Load()
+		if old != nil {
+			if m, ok := (*old)[name]; ok {
+				return m
+			}
+		}
+		next := make(map[string]*statementMetrics)
+		if old != nil {
+			for k, v := range *old {
+				next[k] = v
+			}
+		}
+		m := &statementMetrics{}
+		next[name] = m
+		if old == nil {
+			if h.calls.CompareAndSwap(nil, &next) {
+				return m
+			}
+		} else if h.calls.CompareAndSwap(old, &next) {
+			return m
+		}
+	}
+}
+
+// Metrics returns a snapshot of per-statement call counts, error counts, and
+// total elapsed time.
+func (h *MetricsHook) Metrics() map[string]statementMetrics {
+	snapshot := make(map[string]statementMetrics)
+	loaded := h.calls.Load()
+	if loaded == nil {
+		return snapshot
+	}
+	for name, m := range *loaded {
+		snapshot[name] = statementMetrics{
+			Calls:             atomic.LoadInt64(&m.Calls),
+			Errors:            atomic.LoadInt64(&m.Errors),
+			TotalElapsedNanos: atomic.LoadInt64(&m.TotalElapsedNanos),
+		}
+	}
+	return snapshot
+}
+
+// metricsHookStartKey is an unexported context key, so MetricsHook never
+// collides with a caller's own context values.
+type metricsHookStartKey struct{}
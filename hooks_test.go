@@ -0,0 +1,79 @@
+package liteflow
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestBaseHookIsNoOp(t *testing.T) {
+	var h BaseHook
+	ctx, err := h.BeforeQuery(context.Background(), "stmt", nil)
+	if err != nil {
+		t.Fatalf("BaseHook.BeforeQuery returned error: %v", err)
+	}
+	if ctx == nil {
+		t.Fatal("BaseHook.BeforeQuery returned nil context")
+	}
+	h.AfterQuery(ctx, "stmt", nil, nil, 0)
+
+	ctx, err = h.BeforeMigration(context.Background(), 1, directionUp)
+	if err != nil {
+		t.Fatalf("BaseHook.BeforeMigration returned error: %v", err)
+	}
+	h.AfterMigration(ctx, 1, directionUp, nil, 0)
+}
+
+func TestMetricsHookAccumulates(t *testing.T) {
+	h := &MetricsHook{}
+	ctx, err := h.BeforeQuery(context.Background(), "get_user", nil)
+	if err != nil {
+		t.Fatalf("BeforeQuery returned error: %v", err)
+	}
+	h.AfterQuery(ctx, "get_user", nil, nil, 1)
+
+	ctx, err = h.BeforeQuery(context.Background(), "get_user", nil)
+	if err != nil {
+		t.Fatalf("BeforeQuery returned error: %v", err)
+	}
+	h.AfterQuery(ctx, "get_user", nil, errors.New("boom"), 0)
+
+	snapshot := h.Metrics()
+	m, ok := snapshot["get_user"]
+	if !ok {
+		t.Fatalf("Metrics() = %#v, missing \"get_user\"", snapshot)
+	}
+	if m.Calls != 2 {
+		t.Errorf("Calls = %d, want 2", m.Calls)
+	}
+	if m.Errors != 1 {
+		t.Errorf("Errors = %d, want 1", m.Errors)
+	}
+}
+
+func TestSlogHookLogsOnlySlowQueries(t *testing.T) {
+	var buf bytes.Buffer
+	h := &SlogHook{
+		Threshold: 10 * time.Millisecond,
+		Logger:    slog.New(slog.NewTextHandler(&buf, nil)),
+	}
+
+	ctx, err := h.BeforeQuery(context.Background(), "fast", nil)
+	if err != nil {
+		t.Fatalf("BeforeQuery returned error: %v", err)
+	}
+	h.AfterQuery(ctx, "fast", nil, nil, 0)
+	if buf.Len() != 0 {
+		t.Fatalf("SlogHook logged a query under threshold: %s", buf.String())
+	}
+
+	start := time.Now().Add(-time.Second)
+	slowCtx := context.WithValue(context.Background(), slogHookStartKey{}, start)
+	h.AfterQuery(slowCtx, "slow", nil, nil, 0)
+	if buf.Len() == 0 {
+		t.Fatal("SlogHook did not log a query over threshold")
+	}
+}
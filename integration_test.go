@@ -0,0 +1,188 @@
+package liteflow
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// openMemDB opens a fresh in-memory SQLite database for a single test. Each
+// call opens its own *sql.DB on the ":memory:" DSN, and sqlite's in-memory
+// databases aren't shared across *sql.DB instances.
+func openMemDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("sql.Open returned error: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+const usersMigration = `-- +liteflow Up
+CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL);
+-- +liteflow Down
+DROP TABLE users;
+`
+
+type recordingHook struct {
+	BaseHook
+
+	mu     sync.Mutex
+	events []string
+}
+
+func (h *recordingHook) record(event string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events = append(h.events, event)
+}
+
+func (h *recordingHook) BeforeQuery(ctx context.Context, name string, args []any) (context.Context, error) {
+	h.record("before-query:" + name)
+	return ctx, nil
+}
+
+func (h *recordingHook) AfterQuery(ctx context.Context, name string, args []any, err error, rowsAffected int64) {
+	h.record("after-query:" + name)
+}
+
+func (h *recordingHook) BeforeMigration(ctx context.Context, version int, direction string) (context.Context, error) {
+	h.record("before-migration:" + direction)
+	return ctx, nil
+}
+
+func (h *recordingHook) AfterMigration(ctx context.Context, version int, direction string, err error, duration time.Duration) {
+	h.record("after-migration:" + direction)
+}
+
+// TestIntegrationUpgradeFiresHooksAndBindsQueries runs a real migration and
+// named query against an in-memory SQLite database, checking that hooks fire
+// around both and that NamedExec/Get/Select actually bind and scan rows.
+func TestIntegrationUpgradeFiresHooksAndBindsQueries(t *testing.T) {
+	versionFS := fstest.MapFS{"0001.sql": &fstest.MapFile{Data: []byte(usersMigration)}}
+	queryFS := fstest.MapFS{"users.sql": &fstest.MapFile{Data: []byte(
+		"-- name: insert\nINSERT INTO users (id, name) VALUES (:id, :name)\n" +
+			"-- name: one\nSELECT id, name FROM users WHERE id = :id\n" +
+			"-- name: all\nSELECT id, name FROM users ORDER BY id\n",
+	)}}
+	hook := &recordingHook{}
+
+	db, err := NewContext(context.Background(), openMemDB(t), &Options{
+		VersionFS: versionFS,
+		QueryFS:   queryFS,
+		Hooks:     []Hook{hook},
+	})
+	if err != nil {
+		t.Fatalf("NewContext returned error: %v", err)
+	}
+
+	type user struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	if _, err := db.NamedExec(context.Background(), "users.insert", user{ID: 1, Name: "ada"}); err != nil {
+		t.Fatalf("NamedExec returned error: %v", err)
+	}
+	if _, err := db.NamedExec(context.Background(), "users.insert", user{ID: 2, Name: "grace"}); err != nil {
+		t.Fatalf("NamedExec returned error: %v", err)
+	}
+
+	var got user
+	if err := db.Get(context.Background(), &got, "users.one", map[string]any{"id": 1}); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if got != (user{ID: 1, Name: "ada"}) {
+		t.Errorf("Get() = %+v, want {1 ada}", got)
+	}
+
+	var all []user
+	if err := db.Select(context.Background(), &all, "users.all", nil); err != nil {
+		t.Fatalf("Select returned error: %v", err)
+	}
+	want := []user{{ID: 1, Name: "ada"}, {ID: 2, Name: "grace"}}
+	if len(all) != len(want) || all[0] != want[0] || all[1] != want[1] {
+		t.Errorf("Select() = %+v, want %+v", all, want)
+	}
+
+	hook.mu.Lock()
+	events := append([]string(nil), hook.events...)
+	hook.mu.Unlock()
+	wantSeq := []string{
+		"before-migration:up", "after-migration:up",
+		"before-query:users.insert", "after-query:users.insert",
+		"before-query:users.insert", "after-query:users.insert",
+		"before-query:users.one", "after-query:users.one",
+		"before-query:users.all", "after-query:users.all",
+	}
+	if len(events) != len(wantSeq) {
+		t.Fatalf("hook events = %v, want %v", events, wantSeq)
+	}
+	for i, e := range events {
+		if e != wantSeq[i] {
+			t.Errorf("hook event %d = %q, want %q", i, e, wantSeq[i])
+		}
+	}
+}
+
+// TestIntegrationDirtyMigrationForceRecovery simulates a process dying
+// mid-migration (a dirty history row with no matching clean-up) and checks
+// that NewContext refuses to proceed with ErrDirtyMigration until Force
+// clears it.
+func TestIntegrationDirtyMigrationForceRecovery(t *testing.T) {
+	sqlDB := openMemDB(t)
+	versionFS := fstest.MapFS{"0001.sql": &fstest.MapFile{Data: []byte(usersMigration)}}
+
+	db, err := NewContext(context.Background(), sqlDB, &Options{VersionFS: versionFS})
+	if err != nil {
+		t.Fatalf("NewContext returned error: %v", err)
+	}
+
+	// Simulate a crash mid-migration: a dirty row with no matching
+	// clearDirtyRecord call, bypassing runFileAndSetVersion entirely.
+	if _, err := db.insertDirtyRecord(context.Background(), 2, "0002.sql", directionUp, "deadbeef", time.Now()); err != nil {
+		t.Fatalf("insertDirtyRecord returned error: %v", err)
+	}
+
+	_, err = NewContext(context.Background(), sqlDB, &Options{VersionFS: versionFS, MaxVersion: UpgradeNone})
+	var dirtyErr *ErrDirtyMigration
+	if !errors.As(err, &dirtyErr) {
+		t.Fatalf("NewContext error = %v, want *ErrDirtyMigration", err)
+	}
+	if dirtyErr.Version != 2 {
+		t.Errorf("ErrDirtyMigration.Version = %d, want 2", dirtyErr.Version)
+	}
+
+	if err := db.Force(context.Background(), 2); err != nil {
+		t.Fatalf("Force returned error: %v", err)
+	}
+
+	if _, err := NewContext(context.Background(), sqlDB, &Options{VersionFS: versionFS, MaxVersion: UpgradeNone}); err != nil {
+		t.Fatalf("NewContext after Force returned error: %v", err)
+	}
+}
+
+// TestGetIntoNonStructReturnsError checks that scanning into a destination
+// that isn't a struct (e.g. a single aggregate column) returns an error
+// instead of panicking in reflect.Type.NumField.
+func TestGetIntoNonStructReturnsError(t *testing.T) {
+	queryFS := fstest.MapFS{"count.sql": &fstest.MapFile{Data: []byte(
+		"-- name: zero\nSELECT 0\n",
+	)}}
+	db, err := NewContext(context.Background(), openMemDB(t), &Options{QueryFS: queryFS})
+	if err != nil {
+		t.Fatalf("NewContext returned error: %v", err)
+	}
+
+	var count int
+	if err := db.Get(context.Background(), &count, "count.zero", nil); err == nil {
+		t.Fatal("Get into *int returned nil error, want an error")
+	}
+}
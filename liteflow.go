@@ -3,7 +3,8 @@
 // generate the named statements internally, pass an io/fs.FS (e.g.: embed.FS)
 // which contains the SQL files.
 //
-// This package can also handle database migrations for SQLite.
+// This package can also handle database migrations, for SQLite by default
+// and other backends via the dialect package.
 package liteflow
 
 import (
@@ -17,9 +18,12 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/typeduck/liteflow/dialect"
 )
 
-// DB is an enhanced SQLite *sql.DB with versioning and named statements.
+// DB is an enhanced *sql.DB with versioning and named statements.
 type DB struct {
 	*sql.DB
 
@@ -27,6 +31,7 @@ type DB struct {
 	versionFS  fs.FS          // filesystem of upgrade/downgrade filenames
 	upgrades   map[int]string // version => upgrade filename for version
 	downgrades map[int]string // version => downgrade filename from version
+	dialect    dialect.Dialect
 
 	// Initializations after versioning
 	initFS fs.FS
@@ -34,6 +39,13 @@ type DB struct {
 	// Prepared statement fields
 	queryFS    fs.FS                // filesystem of all prepared statements
 	statements map[string]*sql.Stmt // statement cache
+	paramOrder map[string][]string  // statement name => :name/@name order, for NamedExec/NamedQuery
+
+	// historyTable is the name of the table used to record migration history.
+	historyTable string
+
+	// hooks are called around every query/exec path and migration file run.
+	hooks []Hook
 }
 
 // UpgradeNone indicates to skip upgrading and prepared statements.
@@ -64,19 +76,40 @@ type Options struct {
 	// QueryFS is the sub-directory in the fs.FS which holds all prepared
 	// statements.
 	QueryFS fs.FS
+
+	// HistoryTable is the name of the table used to record migration
+	// history. The default zero value uses "liteflow_migrations".
+	HistoryTable string
+
+	// Dialect selects the SQL backend's version-tracking and
+	// statement-splitting behavior. The default zero value uses
+	// dialect.SQLite, so existing SQLite callers are unaffected.
+	Dialect dialect.Dialect
+
+	// Hooks are called, in order, around every query/exec path and around
+	// every migration file run by Upgrade/Downgrade.
+	Hooks []Hook
 }
 
 // defaultOptions are the default options used when no options are provided.
 var defaultOptions = Options{}
 
 // New creates DB, which is an enhanced *sql.DB with version control and named
-// prepared statements.
+// prepared statements. It is equivalent to calling NewContext with
+// context.Background().
 //
 // See Options documentation for the available configurations.
 //
 // If the returned database is non-nil, it may still be usable even if there
 // were errors.
 func New(db *sql.DB, opts *Options) (*DB, error) {
+	return NewContext(context.Background(), db, opts)
+}
+
+// NewContext is like New but threads ctx through the initial upgrade and
+// initialization scripts, so a slow migration can be cancelled or bounded by
+// a deadline instead of running to completion unconditionally.
+func NewContext(ctx context.Context, db *sql.DB, opts *Options) (*DB, error) {
 	if db == nil {
 		return nil, fmt.Errorf("non-nil *sql.DB reference required")
 	}
@@ -86,30 +119,53 @@ func New(db *sql.DB, opts *Options) (*DB, error) {
 		opts = &defaultOptions
 	}
 
+	historyTable := opts.HistoryTable
+	if historyTable == "" {
+		historyTable = defaultHistoryTable
+	}
+	dia := opts.Dialect
+	if dia == nil {
+		dia = dialect.SQLite
+	}
+
 	d := &DB{
-		DB:         db,
-		versionFS:  opts.VersionFS,
-		upgrades:   make(map[int]string),
-		downgrades: make(map[int]string),
-		initFS:     opts.InitFS,
-		queryFS:    opts.QueryFS,
-		statements: make(map[string]*sql.Stmt),
+		DB:           db,
+		versionFS:    opts.VersionFS,
+		upgrades:     make(map[int]string),
+		downgrades:   make(map[int]string),
+		dialect:      dia,
+		initFS:       opts.InitFS,
+		queryFS:      opts.QueryFS,
+		statements:   make(map[string]*sql.Stmt),
+		paramOrder:   make(map[string][]string),
+		historyTable: historyTable,
+		hooks:        opts.Hooks,
 	}
 
 	// Load the Versioning map to prepare for upgrade.
 	if d.versionFS != nil {
 		errs = append(errs, d.loadVersions()...)
-	}
-
-	// Upgrade database unless inhibited.
-	if d.versionFS != nil && opts.MaxVersion != UpgradeNone {
-		_, err := d.Upgrade(opts.MaxVersion)
-		errs = append(errs, err)
+		if err := d.ensureHistoryTable(ctx); err != nil {
+			errs = append(errs, err)
+		} else if err := d.ensureVersionTable(ctx); err != nil {
+			errs = append(errs, err)
+		} else if dirty, err := d.dirtyRecord(ctx); err != nil {
+			errs = append(errs, err)
+		} else if dirty != nil {
+			errs = append(errs, &ErrDirtyMigration{Version: dirty.Version, Filename: dirty.Filename})
+		} else {
+			errs = append(errs, d.checkChecksums(ctx)...)
+			// Upgrade database unless inhibited.
+			if opts.MaxVersion != UpgradeNone {
+				_, err := d.UpgradeContext(ctx, opts.MaxVersion)
+				errs = append(errs, err)
+			}
+		}
 	}
 
 	// Run any initialization scripts.
 	if d.initFS != nil && !opts.NoPreload {
-		errs = append(errs, d.runInitScripts()...)
+		errs = append(errs, d.runInitScripts(ctx)...)
 	}
 
 	// Preload Statements unless inhibited.
@@ -140,9 +196,16 @@ func (db *DB) loadVersions() []error {
 		}
 		vnum, _ := strconv.Atoi(rxVersion.FindString(entry.Name()))
 		if vnum > 0 {
-			if rxUpgrade.MatchString(entry.Name()) {
+			switch {
+			case rxUpgrade.MatchString(entry.Name()):
+				db.upgrades[vnum] = entry.Name()
+			case rxDowngrade.MatchString(entry.Name()):
+				db.downgrades[vnum] = entry.Name()
+			default:
+				// A combined file (no .up./.down. marker) holds both
+				// directions behind "-- +liteflow Up"/"-- +liteflow Down"
+				// annotations; see parseMigrationFile.
 				db.upgrades[vnum] = entry.Name()
-			} else if rxDowngrade.MatchString(entry.Name()) {
 				db.downgrades[vnum] = entry.Name()
 			}
 		}
@@ -152,7 +215,7 @@ func (db *DB) loadVersions() []error {
 
 // runInitScripts runs any SQL-based start initializations prior to loading
 // statements.
-func (db *DB) runInitScripts() []error {
+func (db *DB) runInitScripts(ctx context.Context) []error {
 	entries, err := fs.ReadDir(db.initFS, ".")
 	if err != nil {
 		return []error{fmt.Errorf("could not read InitFS: %w", err)}
@@ -165,7 +228,7 @@ func (db *DB) runInitScripts() []error {
 		if !strings.HasSuffix(entry.Name(), ".sql") {
 			continue
 		}
-		if err := db.runOneScript(entry.Name()); err != nil {
+		if err := db.runOneScript(ctx, entry.Name()); err != nil {
 			errs = append(errs, err)
 		}
 	}
@@ -173,7 +236,7 @@ func (db *DB) runInitScripts() []error {
 }
 
 // runOneScript runs a single initialization script
-func (db *DB) runOneScript(filename string) error {
+func (db *DB) runOneScript(ctx context.Context, filename string) error {
 	f, err := db.initFS.Open(filename)
 	if err != nil {
 		return fmt.Errorf("could not open SQL init file '%s': %w", filename, err)
@@ -182,11 +245,11 @@ func (db *DB) runOneScript(filename string) error {
 	if err != nil {
 		return fmt.Errorf("could not read SQL init file '%s': %w", filename, err)
 	}
-	tx, err := db.DB.Begin()
+	tx, err := db.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("could not start tx for init file '%s': %w", filename, err)
 	}
-	if _, err := tx.Exec(string(content)); err != nil {
+	if _, err := tx.ExecContext(ctx, string(content)); err != nil {
 		tx.Rollback()
 		return fmt.Errorf("could not run SQL init file '%s' (rolled back): %w", filename, err)
 	}
@@ -245,7 +308,8 @@ func (db *DB) loadStatements(name string) error {
 		if rxNameline.MatchString(line) { // found named query
 			// prepare any buffered lines into current statement.
 			if len(lines) > 0 {
-				joined := strings.Join(lines, "\n")
+				rewritten, names := extractNamedParams(strings.Join(lines, "\n"))
+				joined := db.rebind(rewritten)
 				queryName := name
 				if subname != "" {
 					queryName += "." + subname
@@ -255,6 +319,7 @@ func (db *DB) loadStatements(name string) error {
 					return fmt.Errorf("[%s:%d] could not prepare '%s': %w", fn, lno, queryName, err)
 				}
 				db.statements[queryName] = s
+				db.paramOrder[queryName] = names
 			}
 			// extract the new subname for the following lines / reset buffer
 			parts := rxNameline.FindStringSubmatch(line)
@@ -269,7 +334,8 @@ func (db *DB) loadStatements(name string) error {
 	}
 	// If lines left over (e.g. no named queries), then prepare query now.
 	if len(lines) > 0 {
-		joined := strings.Join(lines, "\n")
+		rewritten, names := extractNamedParams(strings.Join(lines, "\n"))
+		joined := db.rebind(rewritten)
 		queryName := name
 		if subname != "" {
 			queryName += "." + subname
@@ -279,29 +345,70 @@ func (db *DB) loadStatements(name string) error {
 			return fmt.Errorf("[%s:%d] could not prepare '%s': %w", fn, lno, queryName, err)
 		}
 		db.statements[queryName] = s
+		db.paramOrder[queryName] = names
 
 	}
 	return nil
 }
 
-// Version returns the current database version.
-func (db *DB) Version() (int, error) {
-	var vCurr int
-	row := db.DB.QueryRow("PRAGMA user_version")
-	if err := row.Scan(&vCurr); err != nil {
-		return vCurr, fmt.Errorf("could not query user_version: %w", err)
+// rebind rewrites a statement's `?` placeholders into db.dialect's
+// positional form (e.g. `$1`, `$2` for Postgres), à la sqlx's Rebind. SQLite
+// and MySQL both already use `?`, so this is a no-op for them.
+func (db *DB) rebind(query string) string {
+	if db.dialect.Placeholder(1) == "?" {
+		return query
+	}
+	var sb strings.Builder
+	n := 0
+	var inQuote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case inQuote != 0:
+			sb.WriteByte(c)
+			if c == inQuote {
+				inQuote = 0
+			}
+		case c == '\'' || c == '"':
+			inQuote = c
+			sb.WriteByte(c)
+		case c == '?':
+			n++
+			sb.WriteString(db.dialect.Placeholder(n))
+		default:
+			sb.WriteByte(c)
+		}
 	}
-	return vCurr, nil
+	return sb.String()
+}
+
+// Version returns the current database version. It is equivalent to calling
+// VersionContext with context.Background().
+func (db *DB) Version() (int, error) {
+	return db.VersionContext(context.Background())
+}
+
+// VersionContext returns the current database version, using ctx for the
+// underlying query.
+func (db *DB) VersionContext(ctx context.Context) (int, error) {
+	return db.dialect.CurrentVersion(ctx, db.DB)
 }
 
 // Upgrade increments the database to at _most_ the given version. The actual
 // version and any error are returned. Passing a version of zero will upgrade as
-// far as possible.
+// far as possible. It is equivalent to calling UpgradeContext with
+// context.Background().
 func (db *DB) Upgrade(version int) (int, error) {
+	return db.UpgradeContext(context.Background(), version)
+}
+
+// UpgradeContext is like Upgrade, but ctx may cancel the upgrade between (or
+// during) individual migration files.
+func (db *DB) UpgradeContext(ctx context.Context, version int) (int, error) {
 	var vCurr int
 	for {
 		var err error
-		if vCurr, err = db.Version(); err != nil {
+		if vCurr, err = db.VersionContext(ctx); err != nil {
 			return vCurr, err
 		}
 		if version > 0 && vCurr >= version {
@@ -312,7 +419,7 @@ func (db *DB) Upgrade(version int) (int, error) {
 		if !ok {
 			break
 		}
-		if err := db.runFileAndSetVersion(nextFileName, vNext); err != nil {
+		if err := db.runFileAndSetVersion(ctx, nextFileName, vNext, directionUp); err != nil {
 			if errors.Is(err, fs.ErrNotExist) {
 				return vCurr, nil
 			}
@@ -323,12 +430,19 @@ func (db *DB) Upgrade(version int) (int, error) {
 }
 
 // Downgrade decrements the database to the given version. The actual version
-// and any error are returned.
+// and any error are returned. It is equivalent to calling DowngradeContext
+// with context.Background().
 func (db *DB) Downgrade(version int) (int, error) {
+	return db.DowngradeContext(context.Background(), version)
+}
+
+// DowngradeContext is like Downgrade, but ctx may cancel the downgrade
+// between (or during) individual migration files.
+func (db *DB) DowngradeContext(ctx context.Context, version int) (int, error) {
 	var vCurr int
 	for {
 		var err error
-		if vCurr, err = db.Version(); err != nil {
+		if vCurr, err = db.VersionContext(ctx); err != nil {
 			return vCurr, err
 		}
 		if vCurr <= version {
@@ -338,7 +452,7 @@ func (db *DB) Downgrade(version int) (int, error) {
 		if !ok {
 			break
 		}
-		if err := db.runFileAndSetVersion(nextFileName, vCurr-1); err != nil {
+		if err := db.runFileAndSetVersion(ctx, nextFileName, vCurr-1, directionDown); err != nil {
 			if errors.Is(err, fs.ErrNotExist) {
 				return vCurr, nil
 			}
@@ -351,7 +465,33 @@ func (db *DB) Downgrade(version int) (int, error) {
 // runFileAndSetVersion runs the contents of an SQL file and sets the
 // user_version of the database in a single transaction. An error is returned if
 // the operation did not succeed.
-func (db *DB) runFileAndSetVersion(filename string, version int) error {
+//
+// A history row is inserted as dirty before the file runs, then cleared in
+// the same transaction that commits the version bump. An ordinary failure
+// (the file's tx rolls back cleanly) clears the row again outside that tx via
+// clearDirtyAfterRollback, since the schema change it guarded never landed.
+// Only a failure with no such rollback step to confirm (the process dying
+// mid-migration, or tx.Commit itself returning an error) leaves the row
+// dirty, so the next startup can detect it via ErrDirtyMigration instead of
+// silently re-running.
+//
+// A file annotated with "-- +liteflow Up"/"-- +liteflow Down" directives is
+// parsed by parseMigrationFile and run statement-by-statement instead of as
+// a single blob; a section marked "-- +liteflow NoTransaction" runs outside
+// the usual transaction, via runFileNoTransaction, and any failure there is
+// left dirty since statements already committed outside the tx can't be
+// undone.
+func (db *DB) runFileAndSetVersion(ctx context.Context, filename string, version int, direction string) (err error) {
+	start := time.Now()
+	var n int
+	ctx, n, err = db.runBeforeMigration(ctx, version, direction)
+	defer func() {
+		db.runAfterMigration(ctx, n, version, direction, err, time.Since(start))
+	}()
+	if err != nil {
+		return err
+	}
+
 	f, err := db.versionFS.Open(filename)
 	if err != nil {
 		return fmt.Errorf("could not open SQL file '%s': %w", filename, err)
@@ -361,18 +501,38 @@ func (db *DB) runFileAndSetVersion(filename string, version int) error {
 	if err != nil {
 		return fmt.Errorf("could not read SQL file '%s': %w", filename, err)
 	}
-	tx, err := db.DB.Begin()
+
+	rowID, err := db.insertDirtyRecord(ctx, version, filename, direction, checksum(content), start)
 	if err != nil {
-		return fmt.Errorf("could not start tx for file '%s': %w", filename, err)
+		return fmt.Errorf("could not record migration history for file '%s': %w", filename, err)
 	}
-	if _, err := tx.Exec(string(content)); err != nil {
+
+	statements, noTransaction, err := db.migrationStatements(filename, string(content), direction)
+	if err != nil {
+		return db.clearDirtyAfterRollback(rowID, start, fmt.Errorf("could not split SQL file '%s' into statements: %w", filename, err))
+	}
+
+	if noTransaction {
+		return db.runFileNoTransaction(ctx, filename, version, statements, rowID, start)
+	}
+
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return db.clearDirtyAfterRollback(rowID, start, fmt.Errorf("could not start tx for file '%s': %w", filename, err))
+	}
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			tx.Rollback()
+			return db.clearDirtyAfterRollback(rowID, start, fmt.Errorf("could not run SQL file '%s: %w", filename, err))
+		}
+	}
+	if err := db.dialect.SetVersion(ctx, tx, version); err != nil {
 		tx.Rollback()
-		return fmt.Errorf("could not run SQL file '%s: %w", filename, err)
+		return db.clearDirtyAfterRollback(rowID, start, fmt.Errorf("could not set version to %d: %w", version, err))
 	}
-	vUpdateSql := fmt.Sprintf("PRAGMA user_version = %d", version)
-	if _, err := tx.Exec(vUpdateSql); err != nil {
+	if err := db.clearDirtyRecord(ctx, tx, rowID, time.Since(start)); err != nil {
 		tx.Rollback()
-		return fmt.Errorf("could not update user_version to %d: %w", version, err)
+		return db.clearDirtyAfterRollback(rowID, start, fmt.Errorf("could not clear dirty migration history for file '%s': %w", filename, err))
 	}
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("could not commit for file %s, version %d: %w", filename, version, err)
@@ -380,6 +540,46 @@ func (db *DB) runFileAndSetVersion(filename string, version int) error {
 	return nil
 }
 
+// migrationStatements returns the individual statements to run for filename
+// in the given direction ("up" or "down"), and whether they must run
+// outside a transaction. A file with no "-- +liteflow ..." directives falls
+// back to the dialect's whole-file SplitStatements, as before.
+func (db *DB) migrationStatements(filename, content, direction string) ([]string, bool, error) {
+	if !hasMigrationDirectives(content) {
+		statements, err := db.dialect.SplitStatements(content)
+		return statements, false, err
+	}
+	pm, err := parseMigrationFile(filename, content)
+	if err != nil {
+		return nil, false, err
+	}
+	section := &pm.up
+	if direction == directionDown {
+		section = &pm.down
+	}
+	return section.statements, section.noTransaction, nil
+}
+
+// runFileNoTransaction runs statements directly against db.DB, for sections
+// annotated "-- +liteflow NoTransaction" (PRAGMA, VACUUM, and other
+// statements many backends refuse inside a transaction). The version bump
+// and dirty-clear happen as their own statements rather than alongside the
+// migration statements, since there is no enclosing transaction to share.
+func (db *DB) runFileNoTransaction(ctx context.Context, filename string, version int, statements []string, rowID int64, start time.Time) error {
+	for _, stmt := range statements {
+		if _, err := db.DB.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("could not run SQL file '%s' (NoTransaction): %w", filename, err)
+		}
+	}
+	if err := db.dialect.SetVersion(ctx, db.DB, version); err != nil {
+		return fmt.Errorf("could not set version to %d: %w", version, err)
+	}
+	if err := db.clearDirtyRecord(ctx, db.DB, rowID, time.Since(start)); err != nil {
+		return fmt.Errorf("could not clear dirty migration history for file '%s': %w", filename, err)
+	}
+	return nil
+}
+
 // named returns the named statement.
 func (db *DB) named(name string) (*sql.Stmt, error) {
 	s, ok := db.statements[name]
@@ -394,58 +594,77 @@ func (db *DB) named(name string) (*sql.Stmt, error) {
 	return s, nil
 }
 
-// Exec is sql.DB.Exec but with a query name.
+// Exec is sql.DB.Exec but with a query name. It is equivalent to calling
+// ExecContext with context.Background().
 func (db *DB) Exec(name string, args ...any) (sql.Result, error) {
-	s, err := db.named(name)
-	if err != nil {
-		return nil, err
-	}
-	return s.Exec(args...)
+	return db.ExecContext(context.Background(), name, args...)
 }
 
 // ExecContext is sql.DB.ExecContext but with a query name.
 func (db *DB) ExecContext(ctx context.Context, name string, args ...any) (sql.Result, error) {
-	s, err := db.named(name)
+	ctx, n, err := db.runBeforeQuery(ctx, name, args)
 	if err != nil {
+		db.runAfterQuery(ctx, n, name, args, err, 0)
 		return nil, err
 	}
-	return s.ExecContext(ctx, args...)
-}
-
-// Query is sql.DB.Query but with a query name.
-func (db *DB) Query(name string, args ...any) (*sql.Rows, error) {
 	s, err := db.named(name)
 	if err != nil {
+		db.runAfterQuery(ctx, n, name, args, err, 0)
 		return nil, err
 	}
-	return s.Query(args...)
+	res, err := s.ExecContext(ctx, args...)
+	var rowsAffected int64
+	if err == nil {
+		rowsAffected, _ = res.RowsAffected()
+	}
+	db.runAfterQuery(ctx, n, name, args, err, rowsAffected)
+	return res, err
+}
+
+// Query is sql.DB.Query but with a query name. It is equivalent to calling
+// QueryContext with context.Background().
+func (db *DB) Query(name string, args ...any) (*sql.Rows, error) {
+	return db.QueryContext(context.Background(), name, args...)
 }
 
 // QueryContext is sql.DB.QueryContext but with a query name.
 func (db *DB) QueryContext(ctx context.Context, name string, args ...any) (*sql.Rows, error) {
-	s, err := db.named(name)
+	ctx, n, err := db.runBeforeQuery(ctx, name, args)
 	if err != nil {
+		db.runAfterQuery(ctx, n, name, args, err, 0)
 		return nil, err
 	}
-	return s.QueryContext(ctx, args...)
-}
-
-// QueryRow is sql.DB.QueryRow but with a query name.
-func (db *DB) QueryRow(name string, args ...any) (*sql.Row, error) {
 	s, err := db.named(name)
 	if err != nil {
+		db.runAfterQuery(ctx, n, name, args, err, 0)
 		return nil, err
 	}
-	return s.QueryRow(args...), nil
+	rows, err := s.QueryContext(ctx, args...)
+	db.runAfterQuery(ctx, n, name, args, err, 0)
+	return rows, err
+}
+
+// QueryRow is sql.DB.QueryRow but with a query name. It is equivalent to
+// calling QueryRowContext with context.Background().
+func (db *DB) QueryRow(name string, args ...any) (*sql.Row, error) {
+	return db.QueryRowContext(context.Background(), name, args...)
 }
 
 // QueryRowContext is sql.DB.QueryRowContext but with a query name.
 func (db *DB) QueryRowContext(ctx context.Context, name string, args ...any) (*sql.Row, error) {
+	ctx, n, err := db.runBeforeQuery(ctx, name, args)
+	if err != nil {
+		db.runAfterQuery(ctx, n, name, args, err, 0)
+		return nil, err
+	}
 	s, err := db.named(name)
 	if err != nil {
+		db.runAfterQuery(ctx, n, name, args, err, 0)
 		return nil, err
 	}
-	return s.QueryRowContext(ctx, args...), nil
+	row := s.QueryRowContext(ctx, args...)
+	db.runAfterQuery(ctx, n, name, args, nil, 0)
+	return row, nil
 }
 
 // Begin is like sql.DB.Begin, but returns a *liteflow.Tx for named queries.
@@ -489,56 +708,75 @@ func (tx *Tx) named(name string) (*sql.Stmt, error) {
 	return s, nil
 }
 
-// Exec is like sql.Tx.Exec but with a query name.
+// Exec is like sql.Tx.Exec but with a query name. It is equivalent to
+// calling ExecContext with context.Background().
 func (tx *Tx) Exec(name string, args ...any) (sql.Result, error) {
-	s, err := tx.named(name)
-	if err != nil {
-		return nil, err
-	}
-	return s.Exec(args...)
+	return tx.ExecContext(context.Background(), name, args...)
 }
 
 // ExecContext is like sql.Tx.ExecContext but with a query name.
 func (tx *Tx) ExecContext(ctx context.Context, name string, args ...any) (sql.Result, error) {
-	s, err := tx.named(name)
+	ctx, n, err := tx.DB.runBeforeQuery(ctx, name, args)
 	if err != nil {
+		tx.DB.runAfterQuery(ctx, n, name, args, err, 0)
 		return nil, err
 	}
-	return s.ExecContext(ctx, args...)
-}
-
-// Query is like sql.Tx.Query but with a query name.
-func (tx *Tx) Query(name string, args ...any) (*sql.Rows, error) {
 	s, err := tx.named(name)
 	if err != nil {
+		tx.DB.runAfterQuery(ctx, n, name, args, err, 0)
 		return nil, err
 	}
-	return s.Query(args...)
+	res, err := s.ExecContext(ctx, args...)
+	var rowsAffected int64
+	if err == nil {
+		rowsAffected, _ = res.RowsAffected()
+	}
+	tx.DB.runAfterQuery(ctx, n, name, args, err, rowsAffected)
+	return res, err
+}
+
+// Query is like sql.Tx.Query but with a query name. It is equivalent to
+// calling QueryContext with context.Background().
+func (tx *Tx) Query(name string, args ...any) (*sql.Rows, error) {
+	return tx.QueryContext(context.Background(), name, args...)
 }
 
 // QueryContext is like sql.Tx.QueryContext but with a query name.
 func (tx *Tx) QueryContext(ctx context.Context, name string, args ...any) (*sql.Rows, error) {
-	s, err := tx.named(name)
+	ctx, n, err := tx.DB.runBeforeQuery(ctx, name, args)
 	if err != nil {
+		tx.DB.runAfterQuery(ctx, n, name, args, err, 0)
 		return nil, err
 	}
-	return s.QueryContext(ctx, args...)
-}
-
-// QueryRow is like sql.Tx.QueryRow but with a query name.
-func (tx *Tx) QueryRow(name string, args ...any) (*sql.Row, error) {
 	s, err := tx.named(name)
 	if err != nil {
+		tx.DB.runAfterQuery(ctx, n, name, args, err, 0)
 		return nil, err
 	}
-	return s.QueryRow(args...), nil
+	rows, err := s.QueryContext(ctx, args...)
+	tx.DB.runAfterQuery(ctx, n, name, args, err, 0)
+	return rows, err
+}
+
+// QueryRow is like sql.Tx.QueryRow but with a query name. It is equivalent
+// to calling QueryRowContext with context.Background().
+func (tx *Tx) QueryRow(name string, args ...any) (*sql.Row, error) {
+	return tx.QueryRowContext(context.Background(), name, args...)
 }
 
 // QueryRowContext is like sql.Tx.QueryRowContext but with a query name.
 func (tx *Tx) QueryRowContext(ctx context.Context, name string, args ...any) (*sql.Row, error) {
+	ctx, n, err := tx.DB.runBeforeQuery(ctx, name, args)
+	if err != nil {
+		tx.DB.runAfterQuery(ctx, n, name, args, err, 0)
+		return nil, err
+	}
 	s, err := tx.named(name)
 	if err != nil {
+		tx.DB.runAfterQuery(ctx, n, name, args, err, 0)
 		return nil, err
 	}
-	return s.QueryRowContext(ctx, args...), nil
+	row := s.QueryRowContext(ctx, args...)
+	tx.DB.runAfterQuery(ctx, n, name, args, nil, 0)
+	return row, nil
 }
@@ -0,0 +1,220 @@
+package liteflow
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/typeduck/liteflow/dialect"
+)
+
+// defaultHistoryTable is the table name used to record migration history
+// when Options.HistoryTable is left at its zero value.
+const defaultHistoryTable = "liteflow_migrations"
+
+// Migration directions, as recorded in the history table.
+const (
+	directionUp   = "up"
+	directionDown = "down"
+)
+
+// MigrationRecord is a single row of migration history, as recorded by
+// runFileAndSetVersion and returned by DB.History.
+type MigrationRecord struct {
+	Version    int
+	Filename   string
+	Direction  string
+	Checksum   string
+	AppliedAt  time.Time
+	DurationMS int64
+	Dirty      bool
+}
+
+// ErrDirtyMigration indicates that a prior migration was interrupted before
+// it could be marked complete, leaving the database in an unknown state.
+// New/NewContext return it and skip the initial upgrade until the caller
+// resolves this, typically via DB.Force. Upgrade/UpgradeContext and
+// Downgrade/DowngradeContext, called directly, do not check for dirty
+// history rows.
+type ErrDirtyMigration struct {
+	Version  int
+	Filename string
+}
+
+func (e *ErrDirtyMigration) Error() string {
+	return fmt.Sprintf("migration %d (%s) is marked dirty; inspect the database and call DB.Force to clear it before upgrading further", e.Version, e.Filename)
+}
+
+// checksum returns the hex-encoded SHA-256 digest of a migration file's
+// contents, used to detect a file edited after it was applied.
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// ensureHistoryTable creates the migration history table if it does not
+// already exist, using db.dialect's own auto-increment primary-key syntax.
+func (db *DB) ensureHistoryTable(ctx context.Context) error {
+	if _, err := db.DB.ExecContext(ctx, db.dialect.HistoryTableDDL(db.historyTable)); err != nil {
+		return fmt.Errorf("could not create history table '%s': %w", db.historyTable, err)
+	}
+	return nil
+}
+
+// ensureVersionTable creates whatever backing store db.dialect's
+// CurrentVersion/SetVersion need, once per New/NewContext call that has a
+// VersionFS, so that a
+// multi-file Upgrade/Downgrade doesn't reissue the same DDL once per
+// migration via CurrentVersion itself.
+func (db *DB) ensureVersionTable(ctx context.Context) error {
+	if err := db.dialect.EnsureVersionTable(ctx, db.DB); err != nil {
+		return fmt.Errorf("could not ensure version table: %w", err)
+	}
+	return nil
+}
+
+// insertDirtyRecord records the start of a migration as dirty, returning the
+// history row's id to clear once the migration completes successfully.
+func (db *DB) insertDirtyRecord(ctx context.Context, version int, filename, direction, sum string, start time.Time) (int64, error) {
+	return db.dialect.InsertHistoryRecord(ctx, db.DB, db.historyTable, version, filename, direction, sum, start.UTC().Format(time.RFC3339Nano))
+}
+
+// clearDirtyRecord marks history row id as no longer dirty and records its
+// duration. q is either db.DB or the *Tx the migration ran in, so the clear
+// can share the migration's own transaction when there is one.
+func (db *DB) clearDirtyRecord(ctx context.Context, q dialect.Querier, id int64, duration time.Duration) error {
+	clearSql := fmt.Sprintf("UPDATE %s SET dirty = 0, duration_ms = %s WHERE id = %s",
+		db.historyTable, db.dialect.Placeholder(1), db.dialect.Placeholder(2))
+	_, err := q.ExecContext(ctx, clearSql, duration.Milliseconds(), id)
+	return err
+}
+
+// clearDirtyAfterRollback clears the dirty history row left by
+// insertDirtyRecord once a migration's own transaction has rolled back. That
+// rollback already undid the schema change, so the row no longer reflects an
+// unknown state and would otherwise wrongly trip ErrDirtyMigration on the
+// next call; it is cleared with a fresh context and outside any tx of the
+// migration's own, since that tx is gone by the time this runs.
+//
+// migrationErr is the failure that triggered the rollback; it is returned
+// unchanged, annotated with the clear's own error if that also fails.
+func (db *DB) clearDirtyAfterRollback(rowID int64, start time.Time, migrationErr error) error {
+	if clearErr := db.clearDirtyRecord(context.Background(), db.DB, rowID, time.Since(start)); clearErr != nil {
+		return fmt.Errorf("%w (additionally, could not clear dirty migration record: %v)", migrationErr, clearErr)
+	}
+	return migrationErr
+}
+
+// dirtyRecord returns the dirty migration record with the lowest version, if
+// any.
+func (db *DB) dirtyRecord(ctx context.Context) (*MigrationRecord, error) {
+	q := fmt.Sprintf("SELECT version, filename, direction, checksum, applied_at, duration_ms, dirty FROM %s WHERE dirty = 1 ORDER BY version LIMIT 1", db.historyTable)
+	row := db.DB.QueryRowContext(ctx, q)
+	rec, err := scanMigrationRecord(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not query dirty migration history: %w", err)
+	}
+	return rec, nil
+}
+
+// History returns every recorded migration, in application order.
+func (db *DB) History(ctx context.Context) ([]MigrationRecord, error) {
+	q := fmt.Sprintf("SELECT version, filename, direction, checksum, applied_at, duration_ms, dirty FROM %s ORDER BY id", db.historyTable)
+	rows, err := db.DB.QueryContext(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("could not query migration history: %w", err)
+	}
+	defer rows.Close()
+
+	var records []MigrationRecord
+	for rows.Next() {
+		rec, err := scanMigrationRecord(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan migration history row: %w", err)
+		}
+		records = append(records, *rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not read migration history: %w", err)
+	}
+	return records, nil
+}
+
+// migrationRowScanner is satisfied by both *sql.Row and *sql.Rows.
+type migrationRowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanMigrationRecord(s migrationRowScanner) (*MigrationRecord, error) {
+	var rec MigrationRecord
+	var appliedAt string
+	var dirty int
+	if err := s.Scan(&rec.Version, &rec.Filename, &rec.Direction, &rec.Checksum, &appliedAt, &rec.DurationMS, &dirty); err != nil {
+		return nil, err
+	}
+	rec.Dirty = dirty != 0
+	parsed, err := time.Parse(time.RFC3339Nano, appliedAt)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse applied_at %q: %w", appliedAt, err)
+	}
+	rec.AppliedAt = parsed
+	return &rec, nil
+}
+
+// Force clears any dirty migration history and sets the database version
+// directly, without running a migration file. Use this to recover from an
+// ErrDirtyMigration once the schema has been verified or repaired by hand.
+func (db *DB) Force(ctx context.Context, version int) error {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not start tx to force version %d: %w", version, err)
+	}
+	clearSql := fmt.Sprintf("UPDATE %s SET dirty = 0 WHERE dirty = 1", db.historyTable)
+	if _, err := tx.ExecContext(ctx, clearSql); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("could not clear dirty migration history: %w", err)
+	}
+	if err := db.dialect.SetVersion(ctx, tx, version); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("could not force version to %d: %w", version, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit forced version %d: %w", version, err)
+	}
+	return nil
+}
+
+// checkChecksums compares the checksum of every upgrade file recorded in
+// history against the file currently in versionFS, returning one warning
+// error per mismatch (the migration was edited after it was applied).
+func (db *DB) checkChecksums(ctx context.Context) []error {
+	var errs []error
+	for version, filename := range db.upgrades {
+		q := fmt.Sprintf("SELECT checksum FROM %s WHERE version = %s AND direction = %s ORDER BY applied_at DESC LIMIT 1",
+			db.historyTable, db.dialect.Placeholder(1), db.dialect.Placeholder(2))
+		var recorded string
+		if err := db.DB.QueryRowContext(ctx, q, version, directionUp).Scan(&recorded); err != nil {
+			continue // not yet applied, nothing to compare against.
+		}
+		f, err := db.versionFS.Open(filename)
+		if err != nil {
+			continue
+		}
+		content, err := io.ReadAll(f)
+		if err != nil {
+			continue
+		}
+		if current := checksum(content); current != recorded {
+			errs = append(errs, fmt.Errorf("warning: migration %d (%s) was modified after it was applied (checksum %s, now %s)", version, filename, recorded, current))
+		}
+	}
+	return errs
+}
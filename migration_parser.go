@@ -0,0 +1,162 @@
+package liteflow
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/typeduck/liteflow/dialect"
+)
+
+// Migration file directives, goose-style: a line consisting of exactly one
+// of these (ignoring surrounding whitespace) switches parsing mode.
+const (
+	directiveUp             = "-- +liteflow Up"
+	directiveDown           = "-- +liteflow Down"
+	directiveNoTransaction  = "-- +liteflow NoTransaction"
+	directiveStatementBegin = "-- +liteflow StatementBegin"
+	directiveStatementEnd   = "-- +liteflow StatementEnd"
+)
+
+// migrationSection holds one direction's statements parsed from a migration
+// file, plus whether they must run outside the usual per-file transaction
+// (e.g. PRAGMA, VACUUM, or ALTER TABLE forms some backends refuse inside a
+// transaction).
+type migrationSection struct {
+	statements    []string
+	noTransaction bool
+}
+
+// parsedMigration is the result of scanning a migration file for
+// "-- +liteflow ..." directives.
+type parsedMigration struct {
+	up   migrationSection
+	down migrationSection
+}
+
+// ErrMigrationSyntax reports a malformed migration-file annotation, with the
+// filename and 1-indexed line, consistent with the "[file:line]" errors
+// loadStatements already reports for malformed query files.
+type ErrMigrationSyntax struct {
+	Filename string
+	Line     int
+	Message  string
+}
+
+func (e *ErrMigrationSyntax) Error() string {
+	return fmt.Sprintf("[%s:%d] %s", e.Filename, e.Line, e.Message)
+}
+
+// hasMigrationDirectives reports whether content carries at least one
+// "-- +liteflow Up"/"-- +liteflow Down" marker, the signal that it should be
+// parsed with parseMigrationFile instead of run as a single blob via
+// Dialect.SplitStatements, as legacy .up.sql/.down.sql files still are.
+func hasMigrationDirectives(content string) bool {
+	return strings.Contains(content, directiveUp) || strings.Contains(content, directiveDown)
+}
+
+// parseMigrationFile splits a migration file's content into Up and Down
+// sections per the goose-style annotations:
+//
+//	-- +liteflow Up
+//	-- +liteflow NoTransaction
+//	...statements, split on ';'...
+//	-- +liteflow StatementBegin
+//	...one statement, taken verbatim and not split on ';'...
+//	-- +liteflow StatementEnd
+//	-- +liteflow Down
+//	...
+//
+// NoTransaction applies to the whole section it appears in, letting a single
+// file pair statements that must run outside a transaction with ones that
+// should run inside one. StatementBegin/StatementEnd wraps a block (e.g. a
+// PL/pgSQL function body) whose embedded semicolons would otherwise be
+// mistaken for statement separators.
+func parseMigrationFile(filename, content string) (*parsedMigration, error) {
+	pm := &parsedMigration{}
+	var cur *migrationSection
+	var buf strings.Builder
+	inBlock := false
+
+	flush := func(lineNo int) error {
+		text := buf.String()
+		buf.Reset()
+		if cur == nil || strings.TrimSpace(text) == "" {
+			return nil
+		}
+		if inBlock {
+			cur.statements = append(cur.statements, strings.TrimSpace(text))
+			return nil
+		}
+		stmts, err := dialect.SplitOnSemicolons(text)
+		if err != nil {
+			return &ErrMigrationSyntax{Filename: filename, Line: lineNo, Message: err.Error()}
+		}
+		cur.statements = append(cur.statements, stmts...)
+		return nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		switch strings.TrimSpace(line) {
+		case directiveUp:
+			if inBlock {
+				return nil, &ErrMigrationSyntax{filename, lineNo, "Up directive inside an unclosed StatementBegin block"}
+			}
+			if err := flush(lineNo); err != nil {
+				return nil, err
+			}
+			cur = &pm.up
+		case directiveDown:
+			if inBlock {
+				return nil, &ErrMigrationSyntax{filename, lineNo, "Down directive inside an unclosed StatementBegin block"}
+			}
+			if err := flush(lineNo); err != nil {
+				return nil, err
+			}
+			cur = &pm.down
+		case directiveNoTransaction:
+			if cur == nil {
+				return nil, &ErrMigrationSyntax{filename, lineNo, "NoTransaction directive outside of an Up/Down section"}
+			}
+			cur.noTransaction = true
+		case directiveStatementBegin:
+			if cur == nil {
+				return nil, &ErrMigrationSyntax{filename, lineNo, "StatementBegin outside of an Up/Down section"}
+			}
+			if inBlock {
+				return nil, &ErrMigrationSyntax{filename, lineNo, "nested StatementBegin"}
+			}
+			if err := flush(lineNo); err != nil {
+				return nil, err
+			}
+			inBlock = true
+		case directiveStatementEnd:
+			if !inBlock {
+				return nil, &ErrMigrationSyntax{filename, lineNo, "StatementEnd without a matching StatementBegin"}
+			}
+			if err := flush(lineNo); err != nil {
+				return nil, err
+			}
+			inBlock = false
+		default:
+			if cur != nil {
+				buf.WriteString(line)
+				buf.WriteByte('\n')
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not scan migration file '%s': %w", filename, err)
+	}
+	if inBlock {
+		return nil, &ErrMigrationSyntax{filename, lineNo, "StatementBegin not closed before end of file"}
+	}
+	if err := flush(lineNo); err != nil {
+		return nil, err
+	}
+	return pm, nil
+}
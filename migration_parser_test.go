@@ -0,0 +1,99 @@
+package liteflow
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseMigrationFileUpAndDown(t *testing.T) {
+	content := `-- +liteflow Up
+CREATE TABLE t (id INTEGER);
+INSERT INTO t VALUES (1);
+-- +liteflow Down
+DROP TABLE t;
+`
+	pm, err := parseMigrationFile("0001.sql", content)
+	if err != nil {
+		t.Fatalf("parseMigrationFile returned error: %v", err)
+	}
+	wantUp := []string{"CREATE TABLE t (id INTEGER)", "INSERT INTO t VALUES (1)"}
+	if !reflect.DeepEqual(pm.up.statements, wantUp) {
+		t.Errorf("up.statements = %#v, want %#v", pm.up.statements, wantUp)
+	}
+	if pm.up.noTransaction {
+		t.Error("up.noTransaction = true, want false")
+	}
+	wantDown := []string{"DROP TABLE t"}
+	if !reflect.DeepEqual(pm.down.statements, wantDown) {
+		t.Errorf("down.statements = %#v, want %#v", pm.down.statements, wantDown)
+	}
+}
+
+func TestParseMigrationFileNoTransaction(t *testing.T) {
+	content := `-- +liteflow Up
+-- +liteflow NoTransaction
+VACUUM;
+`
+	pm, err := parseMigrationFile("0002.sql", content)
+	if err != nil {
+		t.Fatalf("parseMigrationFile returned error: %v", err)
+	}
+	if !pm.up.noTransaction {
+		t.Error("up.noTransaction = false, want true")
+	}
+	if want := []string{"VACUUM"}; !reflect.DeepEqual(pm.up.statements, want) {
+		t.Errorf("up.statements = %#v, want %#v", pm.up.statements, want)
+	}
+}
+
+func TestParseMigrationFileStatementBlockKeepsEmbeddedSemicolons(t *testing.T) {
+	content := `-- +liteflow Up
+-- +liteflow StatementBegin
+CREATE FUNCTION f() RETURNS int AS $$
+BEGIN
+  RETURN 1;
+END;
+$$ LANGUAGE plpgsql;
+-- +liteflow StatementEnd
+`
+	pm, err := parseMigrationFile("0003.sql", content)
+	if err != nil {
+		t.Fatalf("parseMigrationFile returned error: %v", err)
+	}
+	if len(pm.up.statements) != 1 {
+		t.Fatalf("up.statements = %#v, want exactly one block statement", pm.up.statements)
+	}
+}
+
+func TestParseMigrationFileSyntaxErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+	}{
+		{"NoTransaction outside section", "-- +liteflow NoTransaction\n"},
+		{"StatementEnd without Begin", "-- +liteflow Up\n-- +liteflow StatementEnd\n"},
+		{"unclosed StatementBegin", "-- +liteflow Up\n-- +liteflow StatementBegin\nSELECT 1;\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseMigrationFile("bad.sql", tc.content)
+			if err == nil {
+				t.Fatal("parseMigrationFile returned nil error, want an ErrMigrationSyntax")
+			}
+			var syntaxErr *ErrMigrationSyntax
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("error = %v (%T), want *ErrMigrationSyntax", err, err)
+			}
+		})
+	}
+}
+
+func TestHasMigrationDirectives(t *testing.T) {
+	if hasMigrationDirectives("CREATE TABLE t (id INTEGER);") {
+		t.Error("hasMigrationDirectives() = true for a plain legacy file, want false")
+	}
+	if !hasMigrationDirectives("-- +liteflow Up\nSELECT 1;\n") {
+		t.Error("hasMigrationDirectives() = false, want true")
+	}
+}
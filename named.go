@@ -0,0 +1,343 @@
+package liteflow
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// extractNamedParams rewrites a statement's `:name` / `@name` tokens into
+// positional `?` placeholders, returning the rewritten query and the names in
+// bind order. A doubled colon (`::`, Postgres's cast operator) is left
+// untouched rather than treated as a named parameter.
+func extractNamedParams(query string) (string, []string) {
+	var sb strings.Builder
+	var names []string
+	var inQuote byte
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case inQuote != 0:
+			sb.WriteByte(c)
+			if c == inQuote {
+				inQuote = 0
+			}
+			i++
+		case c == '\'' || c == '"':
+			inQuote = c
+			sb.WriteByte(c)
+			i++
+		case c == ':' && i+1 < len(query) && query[i+1] == ':':
+			// A doubled colon is Postgres's cast operator, not a named
+			// parameter; consume both bytes so the second colon isn't
+			// re-examined as the start of a new one.
+			sb.WriteString("::")
+			i += 2
+		case c == ':' || c == '@':
+			j := i + 1
+			for j < len(query) && isIdentByte(query[j]) {
+				j++
+			}
+			if j > i+1 {
+				names = append(names, query[i+1:j])
+				sb.WriteByte('?')
+				i = j
+			} else {
+				sb.WriteByte(c)
+				i++
+			}
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	return sb.String(), names
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// structFieldsByName maps a struct's bindable column names (its "db" tag, or
+// its field name when no tag is present) to field indexes, lowercased.
+// bindArgs (for NamedExec/NamedQuery) and scanStructRow both lowercase the
+// parameter or column name they look up, so a tag like `db:"UserID"` matches
+// :UserID and a userid column alike.
+func structFieldsByName(t reflect.Type) map[string]int {
+	fields := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Tag.Get("db")
+		if name == "" {
+			name = f.Name
+		} else if idx := strings.Index(name, ","); idx >= 0 {
+			name = name[:idx]
+		}
+		fields[strings.ToLower(name)] = i
+	}
+	return fields
+}
+
+// bindArgs resolves a statement's named parameters against a struct or
+// map[string]any, in the order loadStatements recorded them.
+func bindArgs(names []string, arg any) ([]any, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	if m, ok := arg.(map[string]any); ok {
+		args := make([]any, len(names))
+		for i, n := range names {
+			v, ok := m[n]
+			if !ok {
+				return nil, fmt.Errorf("named parameter '%s' not found in map", n)
+			}
+			args[i] = v
+		}
+		return args, nil
+	}
+
+	v := reflect.ValueOf(arg)
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("named argument must be a struct or map[string]any, got %s", v.Kind())
+	}
+	fieldsByName := structFieldsByName(v.Type())
+	args := make([]any, len(names))
+	for i, n := range names {
+		fi, ok := fieldsByName[strings.ToLower(n)]
+		if !ok {
+			return nil, fmt.Errorf("named parameter '%s' has no matching struct field", n)
+		}
+		args[i] = v.Field(fi).Interface()
+	}
+	return args, nil
+}
+
+// scanStructRow scans the current row of rows into the fields of destStruct,
+// matching columns by the same "db" tag convention as bindArgs. Columns with
+// no matching field are discarded.
+func scanStructRow(rows *sql.Rows, destStruct reflect.Value) error {
+	cols, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("could not read result columns: %w", err)
+	}
+	fieldsByName := structFieldsByName(destStruct.Type())
+	ptrs := make([]any, len(cols))
+	for i, col := range cols {
+		if fi, ok := fieldsByName[strings.ToLower(col)]; ok {
+			ptrs[i] = destStruct.Field(fi).Addr().Interface()
+		} else {
+			var discard any
+			ptrs[i] = &discard
+		}
+	}
+	return rows.Scan(ptrs...)
+}
+
+// NamedExec is like Exec, but arg is a struct or map[string]any bound to the
+// statement's `:name` / `@name` placeholders by field/key name.
+func (db *DB) NamedExec(ctx context.Context, name string, arg any) (sql.Result, error) {
+	s, err := db.named(name)
+	if err != nil {
+		return nil, err
+	}
+	args, err := bindArgs(db.paramOrder[name], arg)
+	if err != nil {
+		return nil, fmt.Errorf("could not bind named args for '%s': %w", name, err)
+	}
+	ctx, n, err := db.runBeforeQuery(ctx, name, args)
+	if err != nil {
+		db.runAfterQuery(ctx, n, name, args, err, 0)
+		return nil, err
+	}
+	res, err := s.ExecContext(ctx, args...)
+	var rowsAffected int64
+	if err == nil {
+		rowsAffected, _ = res.RowsAffected()
+	}
+	db.runAfterQuery(ctx, n, name, args, err, rowsAffected)
+	return res, err
+}
+
+// NamedQuery is like QueryContext, but arg is a struct or map[string]any
+// bound to the statement's `:name` / `@name` placeholders by field/key name.
+func (db *DB) NamedQuery(ctx context.Context, name string, arg any) (*sql.Rows, error) {
+	s, err := db.named(name)
+	if err != nil {
+		return nil, err
+	}
+	args, err := bindArgs(db.paramOrder[name], arg)
+	if err != nil {
+		return nil, fmt.Errorf("could not bind named args for '%s': %w", name, err)
+	}
+	ctx, n, err := db.runBeforeQuery(ctx, name, args)
+	if err != nil {
+		db.runAfterQuery(ctx, n, name, args, err, 0)
+		return nil, err
+	}
+	rows, err := s.QueryContext(ctx, args...)
+	db.runAfterQuery(ctx, n, name, args, err, 0)
+	return rows, err
+}
+
+// Get runs the named statement with arg and scans the single resulting row
+// into dest, which must be a pointer to a struct. It returns sql.ErrNoRows if
+// the query produced no rows.
+func (db *DB) Get(ctx context.Context, dest any, name string, arg any) error {
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Pointer || dv.IsNil() {
+		return fmt.Errorf("Get destination must be a non-nil pointer")
+	}
+	if dv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Get destination must point to a struct, got %s", dv.Elem().Kind())
+	}
+	rows, err := db.NamedQuery(ctx, name, arg)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	if err := scanStructRow(rows, dv.Elem()); err != nil {
+		return fmt.Errorf("could not scan row for '%s': %w", name, err)
+	}
+	return rows.Err()
+}
+
+// Select runs the named statement with arg and scans every resulting row
+// into dest, which must be a pointer to a slice of structs.
+func (db *DB) Select(ctx context.Context, dest any, name string, arg any) error {
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Pointer || dv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("Select destination must be a pointer to a slice")
+	}
+	if dv.Elem().Type().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Select destination must be a pointer to a slice of structs, got %s", dv.Elem().Type().Elem().Kind())
+	}
+	rows, err := db.NamedQuery(ctx, name, arg)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	sliceVal := dv.Elem()
+	elemType := sliceVal.Type().Elem()
+	for rows.Next() {
+		elem := reflect.New(elemType).Elem()
+		if err := scanStructRow(rows, elem); err != nil {
+			return fmt.Errorf("could not scan row for '%s': %w", name, err)
+		}
+		sliceVal.Set(reflect.Append(sliceVal, elem))
+	}
+	return rows.Err()
+}
+
+// NamedExec is like Exec, but arg is a struct or map[string]any bound to the
+// statement's `:name` / `@name` placeholders by field/key name.
+func (tx *Tx) NamedExec(ctx context.Context, name string, arg any) (sql.Result, error) {
+	s, err := tx.named(name)
+	if err != nil {
+		return nil, err
+	}
+	args, err := bindArgs(tx.DB.paramOrder[name], arg)
+	if err != nil {
+		return nil, fmt.Errorf("could not bind named args for '%s': %w", name, err)
+	}
+	ctx, n, err := tx.DB.runBeforeQuery(ctx, name, args)
+	if err != nil {
+		tx.DB.runAfterQuery(ctx, n, name, args, err, 0)
+		return nil, err
+	}
+	res, err := s.ExecContext(ctx, args...)
+	var rowsAffected int64
+	if err == nil {
+		rowsAffected, _ = res.RowsAffected()
+	}
+	tx.DB.runAfterQuery(ctx, n, name, args, err, rowsAffected)
+	return res, err
+}
+
+// NamedQuery is like QueryContext, but arg is a struct or map[string]any
+// bound to the statement's `:name` / `@name` placeholders by field/key name.
+func (tx *Tx) NamedQuery(ctx context.Context, name string, arg any) (*sql.Rows, error) {
+	s, err := tx.named(name)
+	if err != nil {
+		return nil, err
+	}
+	args, err := bindArgs(tx.DB.paramOrder[name], arg)
+	if err != nil {
+		return nil, fmt.Errorf("could not bind named args for '%s': %w", name, err)
+	}
+	ctx, n, err := tx.DB.runBeforeQuery(ctx, name, args)
+	if err != nil {
+		tx.DB.runAfterQuery(ctx, n, name, args, err, 0)
+		return nil, err
+	}
+	rows, err := s.QueryContext(ctx, args...)
+	tx.DB.runAfterQuery(ctx, n, name, args, err, 0)
+	return rows, err
+}
+
+// Get is like DB.Get, run within the transaction.
+func (tx *Tx) Get(ctx context.Context, dest any, name string, arg any) error {
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Pointer || dv.IsNil() {
+		return fmt.Errorf("Get destination must be a non-nil pointer")
+	}
+	if dv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Get destination must point to a struct, got %s", dv.Elem().Kind())
+	}
+	rows, err := tx.NamedQuery(ctx, name, arg)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	if err := scanStructRow(rows, dv.Elem()); err != nil {
+		return fmt.Errorf("could not scan row for '%s': %w", name, err)
+	}
+	return rows.Err()
+}
+
+// Select is like DB.Select, run within the transaction.
+func (tx *Tx) Select(ctx context.Context, dest any, name string, arg any) error {
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Pointer || dv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("Select destination must be a pointer to a slice")
+	}
+	if dv.Elem().Type().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Select destination must be a pointer to a slice of structs, got %s", dv.Elem().Type().Elem().Kind())
+	}
+	rows, err := tx.NamedQuery(ctx, name, arg)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	sliceVal := dv.Elem()
+	elemType := sliceVal.Type().Elem()
+	for rows.Next() {
+		elem := reflect.New(elemType).Elem()
+		if err := scanStructRow(rows, elem); err != nil {
+			return fmt.Errorf("could not scan row for '%s': %w", name, err)
+		}
+		sliceVal.Set(reflect.Append(sliceVal, elem))
+	}
+	return rows.Err()
+}
@@ -0,0 +1,77 @@
+package liteflow
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractNamedParams(t *testing.T) {
+	cases := []struct {
+		name      string
+		query     string
+		wantQuery string
+		wantNames []string
+	}{
+		{
+			name:      "colon and at params",
+			query:     "SELECT * FROM t WHERE id = :id AND owner = @owner",
+			wantQuery: "SELECT * FROM t WHERE id = ? AND owner = ?",
+			wantNames: []string{"id", "owner"},
+		},
+		{
+			name:      "postgres cast operator is left alone",
+			query:     "SELECT x::text FROM t WHERE id = :id",
+			wantQuery: "SELECT x::text FROM t WHERE id = ?",
+			wantNames: []string{"id"},
+		},
+		{
+			name:      "quoted colon is not a parameter",
+			query:     "SELECT ':id' FROM t WHERE id = :id",
+			wantQuery: "SELECT ':id' FROM t WHERE id = ?",
+			wantNames: []string{"id"},
+		},
+		{
+			name:      "bare colon with no identifier is left alone",
+			query:     "SELECT 1 : FROM t",
+			wantQuery: "SELECT 1 : FROM t",
+			wantNames: nil,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotQuery, gotNames := extractNamedParams(tc.query)
+			if gotQuery != tc.wantQuery {
+				t.Errorf("extractNamedParams(%q) query = %q, want %q", tc.query, gotQuery, tc.wantQuery)
+			}
+			if !reflect.DeepEqual(gotNames, tc.wantNames) {
+				t.Errorf("extractNamedParams(%q) names = %#v, want %#v", tc.query, gotNames, tc.wantNames)
+			}
+		})
+	}
+}
+
+type namedTestRow struct {
+	UserID int    `db:"UserID"`
+	Name   string `db:"name"`
+}
+
+func TestStructFieldsByNameIsCaseInsensitive(t *testing.T) {
+	fields := structFieldsByName(reflect.TypeOf(namedTestRow{}))
+	if _, ok := fields["userid"]; !ok {
+		t.Fatalf("structFieldsByName() = %#v, want lowercased key \"userid\" for tag \"UserID\"", fields)
+	}
+	if _, ok := fields["name"]; !ok {
+		t.Fatalf("structFieldsByName() = %#v, want key \"name\"", fields)
+	}
+}
+
+func TestBindArgsMatchesUppercaseTag(t *testing.T) {
+	args, err := bindArgs([]string{"UserID", "name"}, namedTestRow{UserID: 7, Name: "ada"})
+	if err != nil {
+		t.Fatalf("bindArgs returned error: %v", err)
+	}
+	want := []any{7, "ada"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("bindArgs() = %#v, want %#v", args, want)
+	}
+}